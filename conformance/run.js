@@ -0,0 +1,124 @@
+// Runs the conformance fixtures against a parser generated from grammar/slon.pegjs.
+//
+// Usage: node conformance/run.js [fixture.json ...]
+
+var fs = require("fs")
+var path = require("path")
+var peggy = require("peggy")
+
+var grammar = fs.readFileSync(path.join(__dirname, "..", "grammar", "slon.pegjs"), "utf8")
+var parser = peggy.generate(grammar)
+
+function TagValue(tag, value) {
+  this.tag = tag
+  this.value = value
+}
+
+function pad(number, width) {
+  return String(number).padStart(width, "0")
+}
+
+// Converts a parsed value into the JSON form used by the fixtures
+function encode(value) {
+  if (typeof value === "bigint") return { $bigint: value.toString() }
+  if (value instanceof Date) {
+    return { $date: pad(value.getFullYear(), 4) + "-" + pad(value.getMonth() + 1, 2) + "-" + pad(value.getDate(), 2) + "/" +
+      pad(value.getHours(), 2) + ":" + pad(value.getMinutes(), 2) + ":" + pad(value.getSeconds(), 2) + "." + pad(value.getMilliseconds(), 3) }
+  }
+  if (value instanceof Set) return { $set: Array.from(value).map(encode) }
+  if (value instanceof TagValue) return { $tag: value.tag, value: encode(value.value) }
+  if (Array.isArray(value)) return value.map(encode)
+  if (value !== null && typeof value === "object") {
+    if (Object.getPrototypeOf(value) !== Object.prototype) throw new Error("unexpected object prototype")
+    var result = {}
+    Object.keys(value).forEach(function(key) {
+      Object.defineProperty(result, key, { value: encode(value[key]), enumerable: true, writable: true, configurable: true })
+    })
+    return result
+  }
+  return value
+}
+
+function equal(a, b) {
+  if (Array.isArray(a) || Array.isArray(b)) {
+    return Array.isArray(a) && Array.isArray(b) && a.length === b.length && a.every(function(value, index) { return equal(value, b[index]) })
+  }
+  if (a !== null && b !== null && typeof a === "object" && typeof b === "object") {
+    var keys = Object.keys(a)
+    return keys.length === Object.keys(b).length && keys.every(function(key) {
+      return Object.prototype.hasOwnProperty.call(b, key) && equal(a[key], b[key])
+    })
+  }
+  return a === b
+}
+
+// Fixtures name tags instead of passing functions; each becomes a constructor wrapping its value
+function buildOptions(options) {
+  var result = Object.assign({}, options)
+  if (Array.isArray(options.tags)) {
+    result.tags = {}
+    options.tags.forEach(function(tag) {
+      result.tags[tag] = function(value) { return new TagValue(tag, value) }
+    })
+  }
+  return result
+}
+
+// A clock advancing one millisecond per reading, so timeouts trigger deterministically
+function withSlowClock(fn) {
+  var now = Date.now, time = now()
+  Date.now = function() { return time++ }
+  try {
+    return fn()
+  } finally {
+    Date.now = now
+  }
+}
+
+function runCase(fixture, test) {
+  var options = buildOptions(Object.assign({}, fixture.options, test.options))
+  var result, failure
+  try {
+    var parse = function() { return parser.parse(test.input, options) }
+    result = encode(test.slowClock ? withSlowClock(parse) : parse())
+  } catch (e) {
+    if (e.name !== "SyntaxError") return "unexpected " + e.name + ": " + e.message
+    failure = e
+  }
+  if (Object.getOwnPropertyNames(Object.prototype).indexOf("polluted") >= 0) {
+    delete Object.prototype.polluted
+    return "Object.prototype was modified"
+  }
+  if (test.error !== undefined) {
+    if (!failure) return "expected an error, got " + JSON.stringify(result)
+    if (typeof test.error === "string" && failure.message.indexOf(test.error) < 0) return "expected error containing " + JSON.stringify(test.error) + ", got " + JSON.stringify(failure.message)
+    if (test.errorCode !== undefined && failure.code !== test.errorCode) return "expected error code " + test.errorCode + ", got " + failure.code
+    return null
+  }
+  if (failure) return "unexpected error: " + failure.message
+  if (!equal(result, test.expected)) return "expected " + JSON.stringify(test.expected) + ", got " + JSON.stringify(result)
+  return null
+}
+
+var files = process.argv.slice(2)
+if (files.length === 0) {
+  var dir = path.join(__dirname, "fixtures")
+  files = fs.readdirSync(dir).filter(function(name) { return name.endsWith(".json") }).sort().map(function(name) { return path.join(dir, name) })
+}
+
+var passed = 0, failed = 0
+files.forEach(function(file) {
+  var fixture = JSON.parse(fs.readFileSync(file, "utf8"))
+  fixture.cases.forEach(function(test) {
+    var problem = runCase(fixture, test)
+    if (problem === null) {
+      passed++
+    } else {
+      failed++
+      console.log("FAIL " + path.basename(file) + ": " + test.name + "\n  " + problem)
+    }
+  })
+})
+
+console.log(passed + " passed, " + failed + " failed")
+process.exit(failed > 0 ? 1 : 0)